@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// docMount is one entry in the VFS gom doc binds godoc to: an import path
+// mounted at /src/<import path> from an on-disk directory.
+type docMount struct {
+	importPath string
+	dir        string
+}
+
+// runDoc implements `gom doc`. On a legacy GOPATH-style vendor tree, plain
+// godoc already understands vendor/src as part of GOPATH, so it's run as
+// before. Once vendoring is module-aware, godoc itself doesn't know to look
+// in vendor/ and will try the module proxy instead, so gom builds its own
+// mount plan from vendor/modules.txt (or by walking vendor/ directly) and
+// serves docs from that tree offline.
+func runDoc(args []string) error {
+	fs := flag.NewFlagSet("doc", flag.ContinueOnError)
+	httpAddr := fs.String("http", "", "address to serve docs on, e.g. :6060")
+	pkg := fs.String("pkg", "", "print rendered docs for a single vendored import path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !vmode.vendoringEnabled() || vmode == legacyGOPATH {
+		return run(append([]string{"godoc"}, fs.Args()...), None)
+	}
+
+	mounts, err := docMountPlan()
+	if err != nil {
+		return err
+	}
+
+	if *pkg != "" {
+		return printPkgDoc(mounts, *pkg)
+	}
+
+	addr := *httpAddr
+	if addr == "" {
+		addr = ":6060"
+	}
+	return serveDocVFS(mounts, addr)
+}
+
+// docMountPlan builds the list of import-path-to-directory mounts godoc
+// should serve, preferring vendor/modules.txt (which already records every
+// vendored package's import path) and falling back to walking vendor/ and
+// deriving import paths from directory layout when no modules.txt exists.
+func docMountPlan() ([]docMount, error) {
+	modulesTxt := filepath.Join(vendorFolder, "modules.txt")
+	if entries, err := parseModulesTxt(modulesTxt); err == nil {
+		mounts := make([]docMount, 0, len(entries))
+		for _, e := range entries {
+			for _, pkg := range e.packages {
+				mounts = append(mounts, docMount{importPath: pkg, dir: filepath.Join(vendorFolder, pkg)})
+			}
+		}
+		if modulePath, err := readModulePath("go.mod"); err == nil && modulePath != "" {
+			mounts = append(mounts, docMount{importPath: modulePath, dir: "."})
+		}
+		return mounts, nil
+	}
+	return walkVendorForMounts(vendorFolder)
+}
+
+// walkVendorForMounts derives a mount plan directly from vendor/'s
+// directory layout when vendor/modules.txt isn't present: every directory
+// under vendor/ containing at least one .go file is mounted at the import
+// path matching its path relative to vendor/.
+func walkVendorForMounts(vendorDir string) ([]docMount, error) {
+	var mounts []docMount
+	err := filepath.WalkDir(vendorDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == vendorDir {
+			return err
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+				rel, err := filepath.Rel(vendorDir, path)
+				if err != nil {
+					return err
+				}
+				mounts = append(mounts, docMount{importPath: filepath.ToSlash(rel), dir: path})
+				break
+			}
+		}
+		return nil
+	})
+	return mounts, err
+}
+
+// serveDocVFS launches godoc bound to the given mounts via -goroot/http,
+// mounting each import path at /src/<import path> so doc requests resolve
+// entirely against the vendored copies on disk instead of the network.
+func serveDocVFS(mounts []docMount, addr string) error {
+	goroot, cleanup, err := materializeDocGoroot(mounts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	fmt.Fprintf(os.Stderr, "gom: serving vendored docs on %s\n", addr)
+	cmd := exec.Command("godoc", fmt.Sprintf("-goroot=%s", goroot), fmt.Sprintf("-http=%s", addr))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// printPkgDoc renders docs for a single mounted import path to stdout,
+// matching the ergonomics of `go doc <import path>`.
+func printPkgDoc(mounts []docMount, importPath string) error {
+	for _, m := range mounts {
+		if m.importPath == importPath {
+			cmd := exec.Command("go", "doc", "-all", m.dir)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		}
+	}
+	return fmt.Errorf("gom doc: %s is not vendored under %s", importPath, vendorFolder)
+}
+
+// materializeDocGoroot lays out a real GOROOT's pkg/doc/etc alongside a
+// src/ tree that symlinks in the real stdlib sources plus every vendored
+// mount, so godoc -goroot can resolve stdlib imports and render its own
+// assets, not just serve the vendored packages themselves. The returned
+// cleanup must be called once the doc server exits to remove the temp
+// directory.
+func materializeDocGoroot(mounts []docMount) (root string, cleanup func(), err error) {
+	realGoroot := goEnv("GOROOT")["GOROOT"]
+
+	root, err = os.MkdirTemp("", "gom-doc-goroot")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(root) }
+
+	if realGoroot != "" {
+		topLevel, err := os.ReadDir(realGoroot)
+		if err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		for _, e := range topLevel {
+			if e.Name() == "src" {
+				continue
+			}
+			if err := os.Symlink(filepath.Join(realGoroot, e.Name()), filepath.Join(root, e.Name())); err != nil {
+				cleanup()
+				return "", nil, err
+			}
+		}
+	}
+
+	srcDir := filepath.Join(root, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	if realGoroot != "" {
+		if stdlib, err := os.ReadDir(filepath.Join(realGoroot, "src")); err == nil {
+			for _, e := range stdlib {
+				if err := os.Symlink(filepath.Join(realGoroot, "src", e.Name()), filepath.Join(srcDir, e.Name())); err != nil {
+					cleanup()
+					return "", nil, err
+				}
+			}
+		}
+	}
+
+	for _, m := range mounts {
+		dst := filepath.Join(srcDir, m.importPath)
+		// A mount nested under an already-linked mount (e.g. a/b/c after
+		// a/b) is reachable through its ancestor's symlink already.
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		abs, err := filepath.Abs(m.dir)
+		if err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		if err := os.Symlink(abs, dst); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+	return root, cleanup, nil
+}
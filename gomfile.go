@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// gomDependency is one resolved Gomfile entry: an import path, the pinned
+// version (and which kind of pin it is — :version, :tag, :branch, or
+// :commit), the groups it belongs to (empty means it's always installed),
+// and an optional replacement source/version pulled from a go.work or
+// go.mod replace directive.
+type gomDependency struct {
+	importPath     string
+	version        string
+	versionKind    string // "version", "tag", "branch", "commit", or "" when unpinned
+	groups         []string
+	replacePath    string
+	replaceVersion string
+}
+
+var gomDirectivePattern = regexp.MustCompile(`^gom\s+"([^"]+)"(.*)$`)
+var gomOptionPattern = regexp.MustCompile(`:(version|tag|branch|commit)\s*=>\s*"([^"]+)"`)
+var groupStartPattern = regexp.MustCompile(`^group\s+(.+?)\s+do$`)
+var groupNamePattern = regexp.MustCompile(`:(\w+)`)
+
+// parseGomfile reads a Gomfile's `gom "import/path"[, :version|:tag|:branch|
+// :commit => "..."]` directives, honoring `group :name do ... end` blocks
+// the same way Bundler groups a Gemfile: every dependency declared inside
+// one or more nested group blocks is tagged with all of their names, so
+// install can later decide which groups are active.
+func parseGomfile(path string) ([]gomDependency, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []gomDependency
+	var groupStack [][]string
+	for _, raw := range strings.Split(string(b), "\n") {
+		line := strings.TrimSpace(raw)
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "":
+			continue
+		case line == "end":
+			if len(groupStack) > 0 {
+				groupStack = groupStack[:len(groupStack)-1]
+			}
+		case groupStartPattern.MatchString(line):
+			m := groupStartPattern.FindStringSubmatch(line)
+			var names []string
+			for _, g := range groupNamePattern.FindAllStringSubmatch(m[1], -1) {
+				names = append(names, g[1])
+			}
+			groupStack = append(groupStack, names)
+		case gomDirectivePattern.MatchString(line):
+			m := gomDirectivePattern.FindStringSubmatch(line)
+			dep := gomDependency{importPath: m[1]}
+			if opt := gomOptionPattern.FindStringSubmatch(m[2]); opt != nil {
+				dep.versionKind = opt[1]
+				dep.version = opt[2]
+			}
+			for _, names := range groupStack {
+				dep.groups = append(dep.groups, names...)
+			}
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+// splitReplaceTarget splits a replace directive's right-hand side ("path"
+// or "path version") into its path and version parts, so callers never
+// thread a space-containing "path version" string somewhere expecting a
+// bare import path.
+func splitReplaceTarget(target string) (path, version string) {
+	fields := strings.Fields(target)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	if len(fields) == 1 {
+		return fields[0], ""
+	}
+	return fields[0], fields[1]
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// install implements `gom install`: it reads the Gomfile, selects the
+// dependencies enabled for the active groups, fetches and vendors each one
+// (applying --trim-tests when enabled), and, once the toolchain is
+// module-aware, writes vendor/modules.txt alongside it so `go build
+// -mod=vendor` and friends see a consistent tree.
+func install(args []string) error {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	fs.BoolVar(&trimTests, "trim-tests", trimTests, "skip test files, testdata, and test-only dependencies when vendoring")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	allDeps, err := parseGomfile("Gomfile")
+	if err != nil {
+		return err
+	}
+
+	deps := make([]gomDependency, 0, len(allDeps))
+	for _, dep := range allDeps {
+		if dependencyEnabled(dep.groups) {
+			deps = append(deps, dep)
+		}
+	}
+
+	trim := trimTestsEnabled(gomfileGroupOptions("Gomfile"))
+	vendorDir := vendorSrc(vendorFolder)
+
+	for _, dep := range deps {
+		if err := installDependency(vendorDir, dep, trim); err != nil {
+			return err
+		}
+	}
+
+	if moduleAware() {
+		if err := writeModulesTxt(filepath.Join(vendorFolder, "modules.txt"), modulesTxtEntries(deps)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dependencyEnabled reports whether a dependency belonging to groups
+// should be installed under the active environment flags and --groups
+// selection. A dependency with no group is always installed; one in
+// :production/:development/:test is gated on the matching flag, and one in
+// any other named group is gated on --groups listing that name, the same
+// way customGroupList is already threaded through main.go.
+func dependencyEnabled(groups []string) bool {
+	if len(groups) == 0 {
+		return true
+	}
+	for _, g := range groups {
+		switch g {
+		case "production":
+			if *productionEnv {
+				return true
+			}
+		case "development":
+			if *developmentEnv {
+				return true
+			}
+		case "test":
+			if *testEnv {
+				return true
+			}
+		default:
+			for _, cg := range customGroupList {
+				if cg == g {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// installDependency fetches a single Gomfile dependency (or its replace
+// target) into GOPATH if it isn't already there, then copies its import
+// closure into vendorDir. trim selects the test-excluded closure and skips
+// *_test.go/testdata the way copyVendorTree always can.
+func installDependency(vendorDir string, dep gomDependency, trim bool) error {
+	gopath := goEnv("GOPATH")["GOPATH"]
+
+	resolved := dep
+	if dep.replacePath != "" {
+		resolved.importPath = dep.replacePath
+		if dep.replaceVersion != "" {
+			resolved.version = dep.replaceVersion
+			resolved.versionKind = "version"
+		}
+	}
+	srcDir := filepath.Join(gopath, "src", resolved.importPath)
+
+	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+		if err := fetchPackage(resolved, srcDir); err != nil {
+			return err
+		}
+	}
+
+	closure, err := walkImportClosure(gopath, srcDir, !trim)
+	if err != nil {
+		return err
+	}
+
+	srcRoot := filepath.Join(gopath, "src")
+	for dir := range closure {
+		rel, err := filepath.Rel(srcRoot, dir)
+		if err != nil {
+			return err
+		}
+		if err := copyVendorTree(dir, filepath.Join(vendorDir, rel), trim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installPackageTo installs a single resolved import path, at the given
+// version, straight into vendorDir. It's the entry point `gom workspace`
+// uses per dependency, sharing the regular install path's fetch/closure/
+// copy logic instead of duplicating it.
+func installPackageTo(vendorDir, importPath, version string) error {
+	dep := gomDependency{importPath: importPath, version: version}
+	if version != "" {
+		dep.versionKind = "version"
+	}
+	return installDependency(vendorDir, dep, trimTests)
+}
+
+// fetchPackage retrieves a dependency into dir: :tag/:branch/:commit pins
+// are fetched the way gom always has, with a plain git clone followed by a
+// checkout of that ref, since those concepts predate (and aren't expressed
+// by) Go modules; an unpinned or :version dependency goes through `go get
+// -d`, which also understands a trailing @version.
+func fetchPackage(dep gomDependency, dir string) error {
+	switch dep.versionKind {
+	case "branch", "tag", "commit":
+		return fetchPackageVCS(dep.importPath, dep.version, dir)
+	default:
+		target := dep.importPath
+		if dep.version != "" {
+			target = dep.importPath + "@" + dep.version
+		}
+		cmd := exec.Command("go", "get", "-d", target)
+		cmd.Dir = filepath.Dir(dir)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+}
+
+// fetchPackageVCS clones importPath as a git repository into dir and, if
+// ref is set, checks it out, covering the :tag/:branch/:commit forms of a
+// Gomfile pin that `go get` itself has no notion of.
+func fetchPackageVCS(importPath, ref, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		clone := exec.Command("git", "clone", "https://"+importPath+".git", dir)
+		clone.Stdout = os.Stdout
+		clone.Stderr = os.Stderr
+		if err := clone.Run(); err != nil {
+			return err
+		}
+	}
+	if ref == "" {
+		return nil
+	}
+	checkout := exec.Command("git", "checkout", ref)
+	checkout.Dir = dir
+	checkout.Stdout = os.Stdout
+	checkout.Stderr = os.Stderr
+	return checkout.Run()
+}
+
+// gomfileGroupOptions scans a Gomfile for group-level options such as
+// `trim_tests: true`. gom's group blocks are small and flat, so a
+// substring scan is enough without pulling in a full Gomfile DSL parser.
+func gomfileGroupOptions(path string) map[string]string {
+	opts := map[string]string{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return opts
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "trim_tests") && strings.Contains(line, "true") {
+			opts["trim_tests"] = "true"
+		}
+	}
+	return opts
+}
@@ -0,0 +1,21 @@
+package main
+
+import "path/filepath"
+
+// genGomfileLock implements `gom lock`: it resolves the Gomfile into a
+// Gomfile.lock pinning each dependency's version, and, once vendoring is
+// module-aware, refreshes vendor/modules.txt to match so the two files
+// never drift apart.
+func genGomfileLock() error {
+	deps, err := parseGomfile("Gomfile")
+	if err != nil {
+		return err
+	}
+	if err := genGomfileLockTo("Gomfile.lock", deps); err != nil {
+		return err
+	}
+	if moduleAware() {
+		return writeModulesTxt(filepath.Join(vendorFolder, "modules.txt"), modulesTxtEntries(deps))
+	}
+	return nil
+}
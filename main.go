@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/hashicorp/go-version"
 	"github.com/mattn/gover"
 )
 
@@ -19,7 +18,10 @@ func usage() {
                               GOM_VENDOR_NAME=. gom install [options], for regular src folder.
    gom test    [options]   : Run tests with bundles
    gom run     [options]   : Run go file with bundles
-   gom doc     [options]   : Run godoc for bundles
+   gom doc     [options]   : Run godoc for bundles, serving vendored docs
+                              offline when vendoring is module-aware
+                              -http=:6060 to set the listen address
+                              -pkg <import path> to print docs to stdout
    gom exec    [arguments] : Execute command with bundle environment
    gom tool    [options]   : Run go tool with bundles
    gom env     [arguments] : Run go env
@@ -31,6 +33,10 @@ func usage() {
    gom gen gomfile         : Scan packages from current directory as root
                               recursively, and generate Gomfile
    gom lock                : Generate Gomfile.lock
+   gom workspace [command] : Manage a go.work multi-module vendor tree
+                              init|sync|vendor|lock
+   gom import modules-txt  : Seed a Gomfile.lock from an existing
+                              vendor/modules.txt
 `, os.Args[0])
 	os.Exit(1)
 }
@@ -42,18 +48,18 @@ var projectMode = flag.Bool("project-mode", false, "do not move from vendor/src
 var customGroups = flag.String("groups", "", "comma-separated list of Gomfile groups")
 var customGroupList []string
 var vendorFolder string
-var isVendoringSupported bool
+var vmode vendorMode
 
 func init() {
-	isVendoringSupported = checkVendoringSupport()
-	if isVendoringSupported {
-		vendorFolder = "vendor"
-	} else {
+	vmode = detectVendorMode()
+	if vmode == legacyGOPATH && !legacyVendoringSupported(goversion()) {
 		if len(os.Getenv("GOM_VENDOR_NAME")) > 0 {
 			vendorFolder = os.Getenv("GOM_VENDOR_NAME")
 		} else {
 			vendorFolder = "_vendor"
 		}
+	} else {
+		vendorFolder = "vendor"
 	}
 }
 
@@ -62,43 +68,8 @@ func goversion() string {
 	return gover.Version()
 }
 
-// checkVendoringSupport return whether go have native vendor support.
-// If return false, gom behave vendor directory as GOPATH.
-// If return true, gom doesn't move anything.
-func checkVendoringSupport() bool {
-	go150, _ := version.NewVersion("1.5.0")
-	go160, _ := version.NewVersion("1.6.0")
-	go173, _ := version.NewVersion("1.7.3")
-	ver := goversion()
-
-	// TODO: maybe gccgo?
-	if ver == "" {
-		return true
-	}
-
-	goVer, err := version.NewVersion(strings.TrimPrefix(ver, "go"))
-	if err != nil {
-		panic(fmt.Sprintf("gover.Version() returned invalid semantic version: %s", ver))
-	}
-
-	// See: https://golang.org/doc/go1.6#go_command
-	if goVer.LessThan(go150) {
-		return false
-	} else if (goVer.Equal(go150) || goVer.GreaterThan(go150)) && goVer.LessThan(go160) {
-		return os.Getenv("GO15VENDOREXPERIMENT") == "1"
-	} else if (goVer.Equal(go160) || goVer.GreaterThan(go160)) && goVer.LessThan(go173) {
-		return os.Getenv("GO15VENDOREXPERIMENT") != "0"
-	} else {
-		return true
-	}
-}
-
 func vendorSrc(vendor string) string {
-	if isVendoringSupported {
-		return vendor
-	} else {
-		return filepath.Join(vendor, "src")
-	}
+	return vmode.vendorSrc(vendor)
 }
 
 func main() {
@@ -119,15 +90,27 @@ func main() {
 	subArgs := flag.Args()[1:]
 	switch flag.Arg(0) {
 	case "install", "i":
-		err = install(subArgs)
+		if ws, werr := findWorkspace(); werr == nil && ws != nil {
+			err = workspaceSync(ws, subArgs)
+		} else {
+			err = install(subArgs)
+		}
 	case "build", "b":
-		err = run(append([]string{"go", "build"}, subArgs...), None)
+		if ws, werr := findWorkspace(); werr == nil && ws != nil {
+			err = runWorkspaceWide(ws, append([]string{"go", "build"}, subArgs...))
+		} else {
+			err = run(append([]string{"go", "build"}, subArgs...), None)
+		}
 	case "test", "t":
-		err = run(append([]string{"go", "test"}, subArgs...), None)
+		if ws, werr := findWorkspace(); werr == nil && ws != nil {
+			err = runWorkspaceWide(ws, append([]string{"go", "test"}, subArgs...))
+		} else {
+			err = run(append([]string{"go", "test"}, subArgs...), None)
+		}
 	case "run", "r":
 		err = run(append([]string{"go", "run"}, subArgs...), None)
 	case "doc", "d":
-		err = run(append([]string{"godoc"}, subArgs...), None)
+		err = runDoc(subArgs)
 	case "exec", "e":
 		err = run(subArgs, None)
 	case "env", "tool", "fmt", "list", "vet":
@@ -145,6 +128,15 @@ func main() {
 		}
 	case "lock", "l":
 		err = genGomfileLock()
+	case "workspace", "w":
+		err = runWorkspace(subArgs)
+	case "import":
+		switch flag.Arg(1) {
+		case "modules-txt":
+			err = importModulesTxt(filepath.Join(vendorFolder, "modules.txt"), "Gomfile.lock")
+		default:
+			usage()
+		}
 	default:
 		usage()
 	}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// moduleEntry is one module's worth of vendor/modules.txt: its module path
+// and pinned version, whether it's required directly by the Gomfile
+// (## explicit), the package import paths vendored under it, and an
+// optional replace target.
+type moduleEntry struct {
+	module      string
+	version     string
+	explicit    bool
+	packages    []string
+	replace     string
+	replaceVers string
+}
+
+// writeModulesTxt writes vendor/modules.txt in the canonical format the go
+// tool expects: one `# <module> <version>` header per module, optionally
+// followed by `## explicit`, then one line per vendored package import
+// path, then any `=> <replacement> <version>` line for a replace directive.
+func writeModulesTxt(path string, entries []moduleEntry) error {
+	sorted := append([]moduleEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].module < sorted[j].module })
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, e := range sorted {
+		fmt.Fprintf(w, "# %s %s\n", e.module, e.version)
+		if e.explicit {
+			fmt.Fprintln(w, "## explicit")
+		}
+		pkgs := append([]string(nil), e.packages...)
+		sort.Strings(pkgs)
+		for _, pkg := range pkgs {
+			fmt.Fprintln(w, pkg)
+		}
+		if e.replace != "" {
+			fmt.Fprintf(w, "=> %s %s\n", e.replace, e.replaceVers)
+		}
+	}
+	return w.Flush()
+}
+
+// modulesTxtEntries converts a resolved dependency set into moduleEntry
+// records for writeModulesTxt, grouping subpackage dependencies (e.g.
+// github.com/foo/bar/baz) under their true module (github.com/foo/bar)
+// rather than emitting a bogus one-module-per-package header — `go build
+// -mod=vendor` rejects a modules.txt that doesn't match each package to
+// its real module.
+func modulesTxtEntries(deps []gomDependency) []moduleEntry {
+	gopath := goEnv("GOPATH")["GOPATH"]
+
+	byModule := map[string]*moduleEntry{}
+	var order []string
+	for _, d := range deps {
+		// The header names the original module and its pin; a replace
+		// target only goes on the entry's => line.
+		mod := moduleRoot(gopath, d.importPath)
+		e, ok := byModule[mod]
+		if !ok {
+			e = &moduleEntry{module: mod, version: d.version, explicit: true}
+			byModule[mod] = e
+			order = append(order, mod)
+		}
+		e.packages = append(e.packages, d.importPath)
+		if d.replacePath != "" {
+			e.replace = d.replacePath
+			e.replaceVers = d.replaceVersion
+		}
+	}
+
+	entries := make([]moduleEntry, 0, len(order))
+	for _, mod := range order {
+		entries = append(entries, *byModule[mod])
+	}
+	return entries
+}
+
+// moduleRoot walks upward from gopath/src/importPath looking for a go.mod,
+// returning the module path it declares so a vendored subpackage is listed
+// under its owning module rather than as a fake module of its own. Falls
+// back to importPath itself when no go.mod can be found, e.g. because the
+// package hasn't been fetched into GOPATH yet.
+func moduleRoot(gopath, importPath string) string {
+	srcRoot := filepath.Join(gopath, "src")
+	dir := filepath.Join(srcRoot, importPath)
+	for len(dir) >= len(srcRoot) {
+		if mp, err := readModulePath(filepath.Join(dir, "go.mod")); err == nil && mp != "" {
+			return mp
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return importPath
+}
+
+// writeWorkspaceModulesTxt regenerates vendor/modules.txt for a workspace
+// vendor tree: the workspace's resolved dependencies plus one explicit,
+// versionless entry with no packages for every workspace module. All
+// entries are sorted together by module path, so the workspace modules are
+// not grouped ahead of the dependencies.
+func writeWorkspaceModulesTxt(ws *workspace) error {
+	mods, err := ws.loadWorkspaceModules()
+	if err != nil {
+		return err
+	}
+	deps, err := unionWorkspaceDeps(ws, mods)
+	if err != nil {
+		return err
+	}
+	entries := modulesTxtEntries(deps)
+	for _, mod := range mods {
+		entries = append(entries, moduleEntry{module: mod.modulePath, version: "", explicit: true})
+	}
+	return writeModulesTxt(filepath.Join(ws.workspaceVendorFolder(), "modules.txt"), entries)
+}
+
+// parseModulesTxt reads an existing vendor/modules.txt and returns the
+// modules it records, for `gom import modules-txt` to seed a Gomfile.lock
+// from a tree that was previously vendored with `go mod vendor`.
+func parseModulesTxt(path string) ([]moduleEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []moduleEntry
+	var cur *moduleEntry
+	for _, line := range strings.Split(string(b), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			if cur != nil {
+				entries = append(entries, *cur)
+			}
+			fields := strings.Fields(strings.TrimPrefix(line, "# "))
+			cur = &moduleEntry{}
+			if len(fields) > 0 {
+				cur.module = fields[0]
+			}
+			if len(fields) > 1 {
+				cur.version = fields[1]
+			}
+		case strings.HasPrefix(line, "## explicit"):
+			// Go 1.17+ writes "## explicit; go 1.17" instead of the bare
+			// 1.16-and-earlier "## explicit" line; match as a prefix so
+			// both forms set explicit without falling through to the
+			// package-path case below.
+			if cur != nil {
+				cur.explicit = true
+			}
+		case strings.HasPrefix(line, "=> "):
+			if cur != nil {
+				fields := strings.Fields(strings.TrimPrefix(line, "=> "))
+				if len(fields) > 0 {
+					cur.replace = fields[0]
+				}
+				if len(fields) > 1 {
+					cur.replaceVers = fields[1]
+				}
+			}
+		case strings.TrimSpace(line) != "":
+			if cur != nil {
+				cur.packages = append(cur.packages, strings.TrimSpace(line))
+			}
+		}
+	}
+	if cur != nil {
+		entries = append(entries, *cur)
+	}
+	return entries, nil
+}
+
+// importModulesTxt seeds a Gomfile.lock from an existing vendor/modules.txt,
+// so users migrating from `go mod vendor` can adopt gom without losing
+// their pinned versions. Indirect (non-explicit) modules are carried over
+// too — they're just as much a pinned version as a direct one, and
+// dropping them would silently let those dependencies float on next
+// install.
+func importModulesTxt(modulesTxtPath, lockPath string) error {
+	entries, err := parseModulesTxt(modulesTxtPath)
+	if err != nil {
+		return err
+	}
+	deps := make([]gomDependency, 0, len(entries))
+	for _, e := range entries {
+		if e.module == "" {
+			continue
+		}
+		deps = append(deps, gomDependency{importPath: e.module, version: e.version})
+	}
+	return genGomfileLockTo(lockPath, deps)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// trimTests, when set, tells the install path to vendor only the packages
+// (and package files) reachable without following test-only imports, the
+// same size-reduction `go mod vendor` itself applies by leaving _test.go
+// files and testdata/ out of vendor/. It's registered as a flag on the
+// install (and workspace sync/vendor) FlagSets rather than the global one,
+// since -trim-tests only makes sense after the subcommand name.
+var trimTests bool
+
+// licenseFilePattern matches the legal files gom always copies into a
+// vendored package directory, trim-tests or not.
+var licenseFilePattern = regexp.MustCompile(`(?i)^(LICENSE|COPYING|PATENTS|NOTICE|AUTHORS)`)
+
+// trimTestsEnabled reports whether test files and test-only dependencies
+// should be excluded from the vendor tree, either via -trim-tests or a
+// Gomfile group's `trim_tests: true` option.
+func trimTestsEnabled(groupOptions map[string]string) bool {
+	if trimTests {
+		return true
+	}
+	return groupOptions["trim_tests"] == "true"
+}
+
+// importClosure is the set of package directories reachable from a
+// package, expressed as a map for cheap membership tests.
+type importClosure map[string]bool
+
+// walkImportClosure computes the transitive import closure of pkgDir under
+// GOPATH/module root gopath, optionally following each package's test-only
+// imports (TestImports/XTestImports) as well as its regular ones. Passing
+// includeTests=false yields the same trimmed closure `go mod vendor`
+// installs with -mod=vendor: enough to build and run the package, not to
+// test it.
+func walkImportClosure(gopath, pkgDir string, includeTests bool) (importClosure, error) {
+	closure := importClosure{}
+	queue := []string{pkgDir}
+	ctx := build.Default
+	ctx.GOPATH = gopath
+
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if closure[dir] {
+			continue
+		}
+		closure[dir] = true
+
+		pkg, err := ctx.ImportDir(dir, 0)
+		if err != nil {
+			continue
+		}
+		imports := append([]string(nil), pkg.Imports...)
+		if includeTests {
+			imports = append(imports, pkg.TestImports...)
+			imports = append(imports, pkg.XTestImports...)
+		}
+		for _, imp := range imports {
+			if isStdlibImport(imp) {
+				continue
+			}
+			resolved, err := ctx.Import(imp, dir, build.FindOnly)
+			if err != nil {
+				continue
+			}
+			if !closure[resolved.Dir] {
+				queue = append(queue, resolved.Dir)
+			}
+		}
+	}
+	return closure, nil
+}
+
+func isStdlibImport(importPath string) bool {
+	pkg, err := build.Import(importPath, "", build.FindOnly)
+	return err == nil && pkg.Goroot
+}
+
+// copyVendorTree copies srcDir into dstDir, applying trim-tests filtering
+// when requested: *_test.go files and testdata/ directories are skipped
+// entirely, except that legal files (LICENSE*, COPYING*, PATENTS, NOTICE*,
+// AUTHORS*) are always preserved so vendoring still satisfies upstream
+// license requirements.
+func copyVendorTree(srcDir, dstDir string, trim bool) error {
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if trim && rel != "." && d.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dstDir, rel), 0755)
+		}
+		if trim && isTestOnlyFile(d.Name()) {
+			return nil
+		}
+		return copyFile(path, filepath.Join(dstDir, rel))
+	})
+}
+
+// isTestOnlyFile reports whether name should be dropped under trim-tests,
+// unless it's one of the always-preserved legal files.
+func isTestOnlyFile(name string) bool {
+	if licenseFilePattern.MatchString(name) {
+		return false
+	}
+	return len(name) > len("_test.go") && name[len(name)-len("_test.go"):] == "_test.go"
+}
+
+func copyFile(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, info.Mode())
+}
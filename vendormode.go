@@ -0,0 +1,223 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// vendorMode describes how the Go toolchain in PATH will actually resolve
+// imports against a vendor tree. Unlike a single isVendoringSupported bool,
+// it distinguishes the handful of ways a modern `go build` can be told to
+// use (or ignore) vendor/.
+type vendorMode int
+
+const (
+	// legacyGOPATH is the non-module world (GO111MODULE=off, or no go.mod
+	// above cwd), whatever the toolchain's age. On a toolchain without
+	// native vendoring gom moves/copies packages under vendor/src and
+	// manipulates GOPATH itself; on a newer one it fills a plain vendor/
+	// (see vendorSrc).
+	legacyGOPATH vendorMode = iota
+	// moduleAuto is module-aware Go (>=1.14) automatically using vendor/
+	// because a vendor/modules.txt is present and the go.mod `go` version
+	// allows it, with no explicit -mod flag overriding that default.
+	moduleAuto
+	// moduleVendor is an explicit -mod=vendor, either via GOFLAGS or a
+	// future -mod flag on the gom invocation itself.
+	moduleVendor
+	// workspaceVendor is a go.work-based multi-module workspace whose
+	// vendor/ was produced by (or is managed as) a workspace vendor tree.
+	workspaceVendor
+	// off means modules are enabled but vendoring is not: GOFLAGS carries
+	// -mod=mod (or -mod=readonly) and/or no usable vendor tree exists, so
+	// the go tool will hit the module cache/proxy regardless of what gom
+	// puts in vendor/.
+	off
+)
+
+func (m vendorMode) String() string {
+	switch m {
+	case legacyGOPATH:
+		return "legacyGOPATH"
+	case moduleAuto:
+		return "moduleAuto"
+	case moduleVendor:
+		return "moduleVendor"
+	case workspaceVendor:
+		return "workspaceVendor"
+	default:
+		return "off"
+	}
+}
+
+// vendorSrc returns the directory gom should actually populate for this
+// mode. Every module-aware mode vendors straight into <vendor>, matching go
+// mod vendor's layout. legacyGOPATH covers every non-module toolchain, but
+// only the ones without native vendoring (before 1.5, 1.5.x without
+// GO15VENDOREXPERIMENT=1, or 1.6 through 1.7.2 with GO15VENDOREXPERIMENT=0,
+// as legacyVendoringSupported decides) need packages moved under <vendor>/src to
+// double as a synthetic GOPATH; a modern toolchain with modules merely
+// turned off already understands a plain vendor/ directory on its own.
+func (m vendorMode) vendorSrc(vendor string) string {
+	if m == legacyGOPATH && !legacyVendoringSupported(goversion()) {
+		return filepath.Join(vendor, "src")
+	}
+	return vendor
+}
+
+// vendoringEnabled reports whether the go tool will consult vendor/ at all
+// for this mode.
+func (m vendorMode) vendoringEnabled() bool {
+	return m != off
+}
+
+var modFlagPattern = regexp.MustCompile(`-mod[= ](\S+)`)
+
+// goEnv runs `go env` for the given variable names and returns them as a
+// map, tolerating a missing/old go tool by returning an empty map.
+func goEnv(names ...string) map[string]string {
+	out := map[string]string{}
+	cmd := exec.Command("go", append([]string{"env"}, names...)...)
+	b, err := cmd.Output()
+	if err != nil {
+		return out
+	}
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	for i, name := range names {
+		if i < len(lines) {
+			out[name] = strings.Trim(lines[i], `"`)
+		}
+	}
+	return out
+}
+
+// detectVendorMode computes the effective vendorMode for the current
+// environment. It mirrors how golang.org/x/mod's VendorEnabled decides
+// whether `go build` will use vendor/: inspect -mod in GOFLAGS first, then
+// fall back to the go.mod `go` directive plus a top-level vendor/modules.txt,
+// and only drop back to gom's historical pre-module behavior when the
+// toolchain itself predates modules or GO111MODULE/GOPATH mode is forced.
+func detectVendorMode() vendorMode {
+	env := goEnv("GOFLAGS", "GOMOD", "GOWORK", "GOVERSION")
+
+	if m, ok := modFromFlags(env["GOFLAGS"]); ok {
+		return m
+	}
+
+	if env["GOWORK"] != "" && env["GOWORK"] != "off" {
+		if _, err := os.Stat(filepath.Join("vendor", "modules.txt")); err == nil {
+			return workspaceVendor
+		}
+		return off
+	}
+
+	// GOMOD == "" means the go tool isn't operating in module mode at all
+	// (GO111MODULE=off, or no go.mod anywhere above cwd): that's gom's
+	// original GOPATH-vendoring territory, regardless of how new the
+	// toolchain itself is. Whether that means plain vendor/ or the
+	// vendor/src GOPATH emulation is decided by vendorSrc, not here.
+	if env["GOMOD"] == "" || env["GOMOD"] == os.DevNull {
+		return legacyGOPATH
+	}
+
+	if goDirectiveAllowsAutoVendor() {
+		if _, err := os.Stat(filepath.Join("vendor", "modules.txt")); err == nil {
+			return moduleAuto
+		}
+	}
+
+	return off
+}
+
+// moduleAware reports whether gom should maintain vendor/modules.txt for
+// this tree at all: a go.mod exists and its `go` directive is new enough
+// for the toolchain to honor vendoring. Unlike vendorMode, this doesn't
+// require vendor/modules.txt to already exist — install and lock are
+// exactly what create it the first time on a fresh module.
+func moduleAware() bool {
+	if vmode == legacyGOPATH {
+		return false
+	}
+	if _, err := os.Stat("go.mod"); err != nil {
+		return false
+	}
+	return goDirectiveAllowsAutoVendor()
+}
+
+// modFromFlags extracts an explicit -mod value from a GOFLAGS string, if
+// any, and translates it into a vendorMode.
+func modFromFlags(goflags string) (vendorMode, bool) {
+	m := modFlagPattern.FindStringSubmatch(goflags)
+	if m == nil {
+		return 0, false
+	}
+	switch m[1] {
+	case "vendor":
+		return moduleVendor, true
+	case "mod", "readonly", "":
+		return off, true
+	default:
+		return 0, false
+	}
+}
+
+// goDirectiveAllowsAutoVendor reports whether go.mod declares a `go`
+// version new enough (>=1.14) for the toolchain to default to vendoring
+// whenever vendor/modules.txt exists.
+func goDirectiveAllowsAutoVendor() bool {
+	b, err := os.ReadFile("go.mod")
+	if err != nil {
+		return false
+	}
+	go114, _ := version.NewVersion("1.14.0")
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "go ") {
+			continue
+		}
+		v, err := version.NewVersion(strings.TrimSpace(strings.TrimPrefix(line, "go")))
+		if err != nil {
+			return false
+		}
+		return v.Equal(go114) || v.GreaterThan(go114)
+	}
+	return false
+}
+
+// legacyVendoringSupported reproduces gom's original checkVendoringSupport
+// logic for a raw `go version` string, used only once modules are known to
+// be off: Go versions before 1.5 have no vendoring at all, 1.5.x needs
+// GO15VENDOREXPERIMENT=1 opted in, 1.6 through 1.7.2 have it on by default
+// unless explicitly disabled, and 1.7.3+ always has it.
+func legacyVendoringSupported(goVersion string) bool {
+	go150, _ := version.NewVersion("1.5.0")
+	go160, _ := version.NewVersion("1.6.0")
+	go173, _ := version.NewVersion("1.7.3")
+
+	ver := strings.TrimPrefix(goVersion, "go")
+	if ver == "" {
+		ver = strings.TrimPrefix(goversion(), "go")
+	}
+	if ver == "" {
+		return true
+	}
+
+	goVer, err := version.NewVersion(ver)
+	if err != nil {
+		return true
+	}
+
+	if goVer.LessThan(go150) {
+		return false
+	} else if goVer.LessThan(go160) {
+		return os.Getenv("GO15VENDOREXPERIMENT") == "1"
+	} else if goVer.LessThan(go173) {
+		return os.Getenv("GO15VENDOREXPERIMENT") != "0"
+	}
+	return true
+}
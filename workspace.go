@@ -0,0 +1,444 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// workspace describes a discovered go.work file and the modules it lists
+// via `use` directives.
+type workspace struct {
+	root    string   // directory containing go.work
+	goWork  string   // path to go.work itself
+	modDirs []string // absolute paths from each `use` directive
+}
+
+// findWorkspace walks upward from cwd looking for a go.work file, the same
+// way the go tool itself resolves workspace context.
+func findWorkspace() (*workspace, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		goWork := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(goWork); err == nil {
+			return parseGoWork(dir, goWork)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// parseGoWork reads the `use` directives out of a go.work file. It only
+// understands the subset of go.work syntax gom needs: bare and parenthesized
+// `use` blocks with one directory per line.
+func parseGoWork(root, goWork string) (*workspace, error) {
+	b, err := os.ReadFile(goWork)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &workspace{root: root, goWork: goWork}
+	inUseBlock := false
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "":
+			continue
+		case line == "use (":
+			inUseBlock = true
+		case inUseBlock && line == ")":
+			inUseBlock = false
+		case inUseBlock:
+			ws.addUse(line)
+		case strings.HasPrefix(line, "use "):
+			ws.addUse(strings.TrimSpace(strings.TrimPrefix(line, "use")))
+		}
+	}
+	return ws, nil
+}
+
+func (ws *workspace) addUse(dir string) {
+	dir = strings.Trim(dir, `"`)
+	if dir == "" {
+		return
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(ws.root, dir)
+	}
+	ws.modDirs = append(ws.modDirs, dir)
+}
+
+// workspaceModule is one `use`d module, with either its own Gomfile or one
+// synthesized from go.mod's require directives.
+type workspaceModule struct {
+	dir        string
+	modulePath string
+	gomfile    string // path to the Gomfile used, real or synthesized
+}
+
+// loadWorkspaceModules resolves each use directory to its module path and
+// the Gomfile gom should install from, synthesizing one from go.mod when
+// the module doesn't carry its own.
+func (ws *workspace) loadWorkspaceModules() ([]*workspaceModule, error) {
+	mods := make([]*workspaceModule, 0, len(ws.modDirs))
+	for _, dir := range ws.modDirs {
+		modPath, err := readModulePath(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		gomfile := filepath.Join(dir, "Gomfile")
+		if _, err := os.Stat(gomfile); err != nil {
+			gomfile, err = synthesizeGomfile(dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+		mods = append(mods, &workspaceModule{dir: dir, modulePath: modPath, gomfile: gomfile})
+	}
+	return mods, nil
+}
+
+// readModulePath extracts the module path out of a go.mod's `module`
+// directive.
+func readModulePath(goMod string) (string, error) {
+	b, err := os.ReadFile(goMod)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module")), nil
+		}
+	}
+	return "", nil
+}
+
+// synthesizeGomfile builds a throwaway Gomfile in the system temp directory
+// by turning each go.mod `require` line into a `gom` directive, for
+// workspace modules that
+// have adopted go.mod but not (yet) a Gomfile of their own.
+func synthesizeGomfile(dir string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	inRequireBlock := false
+	for _, line := range strings.Split(string(b), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "require (":
+			inRequireBlock = true
+		case inRequireBlock && trimmed == ")":
+			inRequireBlock = false
+		case inRequireBlock:
+			writeGomDirective(&out, trimmed)
+		case strings.HasPrefix(trimmed, "require "):
+			writeGomDirective(&out, strings.TrimSpace(strings.TrimPrefix(trimmed, "require")))
+		}
+	}
+
+	path := filepath.Join(os.TempDir(), "gom-workspace-"+filepath.Base(dir)+"-Gomfile")
+	if err := os.WriteFile(path, []byte(out.String()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func writeGomDirective(out *strings.Builder, requireLine string) {
+	fields := strings.Fields(requireLine)
+	if len(fields) < 2 {
+		return
+	}
+	out.WriteString(`gom "` + fields[0] + `", :version => "` + fields[1] + `"` + "\n")
+}
+
+// workspaceVendorFolder is the single top-level vendor/ that gom workspace
+// populates for every module in the workspace, mirroring `go mod vendor`'s
+// workspace flattening.
+func (ws *workspace) workspaceVendorFolder() string {
+	return filepath.Join(ws.root, "vendor")
+}
+
+// runWorkspace dispatches `gom workspace <subcommand>`.
+func runWorkspace(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return nil
+	}
+
+	ws, err := findWorkspace()
+	if err != nil {
+		return err
+	}
+	if ws == nil {
+		return errNoGoWork
+	}
+
+	switch args[0] {
+	case "init":
+		return workspaceInit(ws)
+	case "sync":
+		return workspaceSync(ws, args[1:])
+	case "vendor":
+		return workspaceVendor(ws, args[1:])
+	case "lock":
+		return workspaceLock(ws)
+	default:
+		usage()
+		return nil
+	}
+}
+
+var errNoGoWork = errNoGoWorkFile()
+
+func errNoGoWorkFile() error {
+	return &workspaceError{"no go.work file found in this directory or any parent"}
+}
+
+type workspaceError struct{ msg string }
+
+func (e *workspaceError) Error() string { return e.msg }
+
+// workspaceInit sets up gom's view of an existing go.work: it just confirms
+// every use directory is loadable and reports the discovered modules, since
+// go.work itself is left to `go work init`/`go work use`.
+func workspaceInit(ws *workspace) error {
+	mods, err := ws.loadWorkspaceModules()
+	if err != nil {
+		return err
+	}
+	for _, mod := range mods {
+		fmt.Printf("%s\t%s\n", mod.modulePath, mod.dir)
+	}
+	return nil
+}
+
+// workspaceSync installs each workspace module's dependencies into the
+// shared vendor/ at the workspace root, honoring replace directives from
+// both go.work and the owning module's go.mod. args is the subArgs tail of
+// `gom install`/`gom workspace sync`, parsed the same way install() parses
+// its own, so e.g. --trim-tests still works when gom auto-detects a
+// workspace instead of silently being dropped.
+func workspaceSync(ws *workspace, args []string) error {
+	fs := flag.NewFlagSet("workspace sync", flag.ContinueOnError)
+	fs.BoolVar(&trimTests, "trim-tests", trimTests, "skip test files, testdata, and test-only dependencies when vendoring")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mods, err := ws.loadWorkspaceModules()
+	if err != nil {
+		return err
+	}
+	deps, err := unionWorkspaceDeps(ws, mods)
+	if err != nil {
+		return err
+	}
+	return installDepsTo(vmode.vendorSrc(ws.workspaceVendorFolder()), deps)
+}
+
+// runWorkspaceWide runs the same go subcommand (e.g. "go build ./...") in
+// every workspace module's directory in turn, so `gom build`/`gom test`
+// auto-detected against a go.work cover the whole graph instead of just
+// whichever module cwd happens to be in. It chdirs into each module and
+// calls gom's regular run(), the same entry point single-module build/test
+// use, so the vendor/GOPATH environment run() sets up isn't bypassed.
+func runWorkspaceWide(ws *workspace, goArgs []string) error {
+	mods, err := ws.loadWorkspaceModules()
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(cwd)
+
+	for _, mod := range mods {
+		if err := os.Chdir(mod.dir); err != nil {
+			return err
+		}
+		if err := run(goArgs, None); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workspaceVendor is an alias for sync that also (re)writes
+// vendor/modules.txt, matching `go mod vendor`'s combined behavior.
+func workspaceVendor(ws *workspace, args []string) error {
+	if err := workspaceSync(ws, args); err != nil {
+		return err
+	}
+	return writeWorkspaceModulesTxt(ws)
+}
+
+// workspaceLock emits a combined Gomfile.lock at the workspace root
+// covering every module's resolved dependencies.
+func workspaceLock(ws *workspace) error {
+	mods, err := ws.loadWorkspaceModules()
+	if err != nil {
+		return err
+	}
+	deps, err := unionWorkspaceDeps(ws, mods)
+	if err != nil {
+		return err
+	}
+	return genGomfileLockTo(filepath.Join(ws.root, "Gomfile.lock"), deps)
+}
+
+// unionWorkspaceDeps merges every workspace module's Gomfile dependency set
+// into one, letting a later module's replace directive override an earlier
+// module's pin for the same import path (the same "last one wins" rule
+// go.work itself uses for overlapping replace directives).
+func unionWorkspaceDeps(ws *workspace, mods []*workspaceModule) ([]gomDependency, error) {
+	byImportPath := map[string]gomDependency{}
+	order := make([]string, 0)
+
+	woWorkReplaces, err := readGoWorkReplaces(ws.goWork)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mod := range mods {
+		deps, err := parseGomfile(mod.gomfile)
+		if err != nil {
+			return nil, err
+		}
+		modReplaces, err := readGoModReplaces(filepath.Join(mod.dir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deps {
+			if r, ok := woWorkReplaces[d.importPath]; ok {
+				d.replacePath, d.replaceVersion = splitReplaceTarget(r)
+			} else if r, ok := modReplaces[d.importPath]; ok {
+				d.replacePath, d.replaceVersion = splitReplaceTarget(r)
+			}
+			if _, seen := byImportPath[d.importPath]; !seen {
+				order = append(order, d.importPath)
+			}
+			byImportPath[d.importPath] = d
+		}
+	}
+
+	result := make([]gomDependency, 0, len(order))
+	for _, p := range order {
+		result = append(result, byImportPath[p])
+	}
+	return result, nil
+}
+
+// goWorkReplacePattern matches a single-line `replace a => b` directive,
+// with the optional "replace" keyword the block-body form omits since it's
+// already inside a `replace ( ... )` block.
+var goWorkReplacePattern = regexp.MustCompile(`^(?:replace\s+)?(\S+)\s*(?:v\S+)?\s*=>\s*(.+)$`)
+
+// readGoWorkReplaces parses the `replace` directives out of a go.work file.
+func readGoWorkReplaces(goWork string) (map[string]string, error) {
+	return readReplaceDirectives(goWork)
+}
+
+// readGoModReplaces parses the `replace` directives out of a go.mod file.
+func readGoModReplaces(goMod string) (map[string]string, error) {
+	return readReplaceDirectives(goMod)
+}
+
+// readReplaceDirectives parses both the single-line `replace a => b` form
+// and the block form:
+//
+//	replace (
+//		a => b
+//		c => d
+//	)
+//
+// used interchangeably in go.work and go.mod. Block-body lines carry no
+// leading "replace" keyword, but goWorkReplacePattern already treats that
+// keyword as optional, so the same pattern matches both forms.
+func readReplaceDirectives(path string) (map[string]string, error) {
+	replaces := map[string]string{}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return replaces, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inReplaceBlock := false
+	for _, raw := range strings.Split(string(b), "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "replace (":
+			inReplaceBlock = true
+			continue
+		case inReplaceBlock && line == ")":
+			inReplaceBlock = false
+			continue
+		}
+		if m := goWorkReplacePattern.FindStringSubmatch(line); m != nil {
+			replaces[m[1]] = strings.TrimSpace(m[2])
+		}
+	}
+	return replaces, nil
+}
+
+// installDepsTo installs each dependency's resolved source into vendorDir,
+// honoring any replace target in preference to the recorded import path.
+// It delegates the actual fetch/closure/copy to gom's regular installer
+// through installPackageTo, which passes each pin on as a :version pin:
+// a :tag/:branch/:commit pin goes through `go get path@ref` rather than a
+// git clone and checkout, Gomfile groups are not filtered, and only the
+// -trim-tests flag (not a Gomfile trim_tests option) selects trimming.
+func installDepsTo(vendorDir string, deps []gomDependency) error {
+	for _, dep := range deps {
+		src := dep.importPath
+		version := dep.version
+		if dep.replacePath != "" {
+			src = dep.replacePath
+			if dep.replaceVersion != "" {
+				version = dep.replaceVersion
+			}
+		}
+		if err := installPackageTo(vendorDir, src, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genGomfileLockTo writes a Gomfile.lock-format file listing each
+// dependency's import path and pinned version, one per line, sorted by
+// import path the way genGomfileLock keeps the single-module lock file
+// deterministic.
+func genGomfileLockTo(path string, deps []gomDependency) error {
+	sorted := append([]gomDependency(nil), deps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].importPath < sorted[j].importPath })
+
+	var out strings.Builder
+	for _, d := range sorted {
+		out.WriteString(d.importPath)
+		if d.version != "" {
+			out.WriteString(" " + d.version)
+		}
+		out.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(out.String()), 0644)
+}